@@ -0,0 +1,370 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"runtime"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/akirak/contributors/pkg/api"
+	"github.com/akirak/contributors/pkg/blame"
+	"github.com/akirak/contributors/pkg/detect"
+	"github.com/akirak/contributors/pkg/identity"
+	"github.com/akirak/contributors/pkg/refresh"
+	"github.com/akirak/contributors/pkg/render"
+	"github.com/akirak/contributors/pkg/report"
+	"github.com/akirak/contributors/pkg/stats"
+)
+
+type Config struct {
+	Name            string
+	Root            string
+	Listen          string
+	Threshold       int
+	UseLinguist     bool
+	CachePath       string
+	NoCache         bool
+	Jobs            int
+	Output          string
+	OutputFile      string
+	NoServe         bool
+	ExcludeBots     bool
+	RefreshInterval time.Duration
+	RefreshToken    string
+}
+
+func makeAbsolute(pathArg string) (string, error) {
+	cwd, cwdErr := os.Getwd()
+	if cwdErr != nil {
+		return pathArg, fmt.Errorf("Error from Getwd: %v", cwdErr)
+	}
+
+	if path.IsAbs(pathArg) {
+		return pathArg, nil
+	} else {
+		return path.Join(cwd, pathArg), nil
+	}
+}
+
+func verifyConfig(config *Config) error {
+	rootInfo, rootErr := os.Stat(config.Root)
+	if rootErr != nil {
+		return fmt.Errorf("Root error: %v", rootErr)
+	} else if !rootInfo.IsDir() {
+		return fmt.Errorf("Root error: %s is not a directory", config.Root)
+	}
+	return nil
+}
+
+// serve starts the HTTP server, reading the served Result from store on
+// every request so a background refresh is picked up without a restart.
+func serve(config *Config, store *refresh.Store, scheduler *refresh.Scheduler) error {
+	mux := http.NewServeMux()
+
+	mux.Handle("/api/v1/", api.Handler(store.Load))
+	mux.HandleFunc("/", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		render.HandleHome(render.Options{Name: config.Name, Threshold: config.Threshold}, store.Load(), w)
+	})
+	mux.HandleFunc("/refresh", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if config.RefreshToken != "" && r.Header.Get("Authorization") != "Bearer "+config.RefreshToken {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if err := scheduler.Refresh(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	fmt.Printf("Listening on %s...\n", config.Listen)
+
+	return http.ListenAndServe(config.Listen, mux)
+}
+
+// writeReport renders result to config.OutputFile (or stdout when unset) in
+// config.Output format, for --no-serve one-shot runs.
+func writeReport(config *Config, result *stats.Result) error {
+	out := io.Writer(os.Stdout)
+	if config.OutputFile != "" {
+		file, createErr := os.Create(config.OutputFile)
+		if createErr != nil {
+			return createErr
+		}
+		defer file.Close()
+		out = file
+	}
+
+	if config.Output == "html" {
+		render.HandleHome(render.Options{Name: config.Name, Threshold: config.Threshold}, result, out)
+		return nil
+	}
+
+	return report.Write(report.Format(config.Output), result, out)
+}
+
+// analyseFunc builds the closure that runs a full repository analysis,
+// sharing the blame cache and identity resolver across repeated calls so
+// a background refresh stays incremental.
+func analyseFunc(config *Config, cache *blame.Cache, resolver *identity.Resolver) refresh.AnalyseFunc {
+	return func() (*stats.Result, error) {
+		var contents detect.RepoContents
+		var contentsError error
+		if config.UseLinguist {
+			contents, contentsError = detect.RunLinguist(config.Root)
+		} else {
+			contents, contentsError = detect.RunEnry(config.Root)
+		}
+		if contentsError != nil {
+			return nil, fmt.Errorf("Error: %v", contentsError)
+		}
+
+		languageStats, statsError := stats.Compute(stats.Options{
+			Root:        config.Root,
+			Jobs:        config.Jobs,
+			Cache:       cache,
+			Identity:    resolver,
+			ExcludeBots: config.ExcludeBots,
+		}, contents)
+		if statsError != nil {
+			return nil, fmt.Errorf("Error: %v", statsError)
+		}
+
+		sha, shaErr := blame.HeadCommit(config.Root)
+		if shaErr != nil {
+			sha = ""
+		}
+
+		return &stats.Result{
+			Contents:      contents,
+			LanguageStats: languageStats,
+			CommitSHA:     sha,
+			AnalysedAt:    time.Now(),
+		}, nil
+	}
+}
+
+func runApp(config *Config) error {
+	fmt.Printf("Analysing the repository %s...\n", config.Root)
+
+	var cache *blame.Cache
+	if !config.NoCache {
+		openedCache, cacheErr := blame.OpenCache(config.CachePath)
+		if cacheErr != nil {
+			return fmt.Errorf("Error from the blame cache: %v", cacheErr)
+		}
+		cache = openedCache
+		defer cache.Close()
+	}
+
+	identityConfig, identityConfigErr := identity.LoadConfig(config.Root)
+	if identityConfigErr != nil {
+		return fmt.Errorf("Error from .contribauthors: %v", identityConfigErr)
+	}
+	resolver, resolverErr := identity.NewResolver(config.Root, identityConfig)
+	if resolverErr != nil {
+		return fmt.Errorf("Error from .contribauthors: %v", resolverErr)
+	}
+
+	analyse := analyseFunc(config, cache, resolver)
+
+	result, analyseErr := analyse()
+	if analyseErr != nil {
+		return analyseErr
+	}
+
+	if config.NoServe {
+		return writeReport(config, result)
+	}
+
+	store := &refresh.Store{}
+	store.Store(result)
+
+	scheduler := refresh.NewScheduler(config.Root, config.RefreshInterval, analyse, store)
+	stop := make(chan struct{})
+	defer close(stop)
+	go scheduler.Run(stop)
+
+	return serve(config, store, scheduler)
+}
+
+// repoRootArg resolves the repo root from the first positional argument,
+// defaulting to the current directory when none is given.
+func repoRootArg(c *cli.Context) (string, error) {
+	if c.NArg() > 0 {
+		return makeAbsolute(c.Args().First())
+	}
+	return os.Getwd()
+}
+
+// resolveCachePath returns cachePath if non-empty, otherwise the default
+// cache path for a repo named repoName.
+func resolveCachePath(cachePath, repoName string) (string, error) {
+	if cachePath != "" {
+		return cachePath, nil
+	}
+	return blame.DefaultCachePath(repoName)
+}
+
+func main() {
+	app := &cli.App{
+		Name:  "contributors",
+		Usage: "Analyse contributors of the project",
+		Flags: []cli.Flag{
+			&cli.IntFlag{
+				Name:    "port",
+				Aliases: []string{"p"},
+				Value:   8888,
+				Usage:   "Port number",
+			},
+			&cli.IntFlag{
+				Name:  "threshold",
+				Value: 15,
+				Usage: "Ignore those who contributed less than `LINES`",
+			},
+			&cli.BoolFlag{
+				Name:  "use-linguist",
+				Value: false,
+				Usage: "Shell out to the external linguist binary instead of the built-in classifier",
+			},
+			&cli.StringFlag{
+				Name:  "cache-path",
+				Usage: "Path to the sqlite blame cache (default ~/.cache/contributors/<repo>.db)",
+			},
+			&cli.BoolFlag{
+				Name:  "no-cache",
+				Usage: "Disable the blame cache and re-blame every file",
+			},
+			&cli.IntFlag{
+				Name:    "jobs",
+				Aliases: []string{"j"},
+				Value:   runtime.NumCPU(),
+				Usage:   "Number of parallel `git blame` workers",
+			},
+			&cli.StringFlag{
+				Name:  "output",
+				Value: "html",
+				Usage: "Output format: html, json, csv, or ndjson",
+			},
+			&cli.StringFlag{
+				Name:  "output-file",
+				Usage: "Write the --no-serve report to `FILE` instead of stdout",
+			},
+			&cli.BoolFlag{
+				Name:  "no-serve",
+				Usage: "Analyse once, write the report, and exit instead of starting the HTTP server",
+			},
+			&cli.BoolFlag{
+				Name:  "exclude-bots",
+				Usage: "Hide the aggregated \"bots\" identity from the report",
+			},
+			&cli.DurationFlag{
+				Name:  "refresh-interval",
+				Usage: "Also re-analyse once this much time has passed since the last analysis, even if HEAD hasn't changed; checked every 30s (0 disables)",
+			},
+			&cli.StringFlag{
+				Name:  "refresh-token",
+				Usage: "If set, POST /refresh requires \"Authorization: Bearer TOKEN\"",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			config := Config{}
+			root, rootErr := repoRootArg(c)
+			if rootErr != nil {
+				return rootErr
+			}
+			config.Root = root
+			config.Listen = fmt.Sprintf(":%d", c.Int("port"))
+			config.Threshold = c.Int("threshold")
+			config.UseLinguist = c.Bool("use-linguist")
+			config.NoCache = c.Bool("no-cache")
+			config.Jobs = c.Int("jobs")
+			config.Output = c.String("output")
+			config.OutputFile = c.String("output-file")
+			config.NoServe = c.Bool("no-serve")
+			config.ExcludeBots = c.Bool("exclude-bots")
+			config.RefreshInterval = c.Duration("refresh-interval")
+			config.RefreshToken = c.String("refresh-token")
+			_, name := path.Split(config.Root)
+			config.Name = name
+
+			cachePath, cachePathErr := resolveCachePath(c.String("cache-path"), config.Name)
+			if cachePathErr != nil {
+				return cachePathErr
+			}
+			config.CachePath = cachePath
+
+			configError := verifyConfig(&config)
+			if configError != nil {
+				return configError
+			}
+
+			return runApp(&config)
+		},
+		Commands: []*cli.Command{
+			{
+				Name:  "cache",
+				Usage: "Manage the blame cache",
+				Subcommands: []*cli.Command{
+					{
+						Name:  "prune",
+						Usage: "Drop cache rows for files no longer present in the repo",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "cache-path",
+								Usage: "Path to the sqlite blame cache (default ~/.cache/contributors/<repo>.db)",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							root, rootErr := repoRootArg(c)
+							if rootErr != nil {
+								return rootErr
+							}
+							_, name := path.Split(root)
+
+							cachePath, cachePathErr := resolveCachePath(c.String("cache-path"), name)
+							if cachePathErr != nil {
+								return cachePathErr
+							}
+
+							cache, cacheErr := blame.OpenCache(cachePath)
+							if cacheErr != nil {
+								return cacheErr
+							}
+							defer cache.Close()
+
+							present, filesErr := blame.ListRepoFiles(root)
+							if filesErr != nil {
+								return filesErr
+							}
+
+							pruned, pruneErr := cache.Prune(root, present)
+							if pruneErr != nil {
+								return pruneErr
+							}
+
+							fmt.Printf("Pruned %d stale cache entries\n", pruned)
+							return nil
+						},
+					},
+				},
+			},
+		},
+	}
+
+	err := app.Run(os.Args)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,75 @@
+// Package api serves a stats.Result as the /api/v1 JSON API.
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/akirak/contributors/pkg/stats"
+)
+
+func etagFor(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+func writeJSON(w http.ResponseWriter, r *http.Request, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	etag := etagFor(body)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag)
+
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Write(body)
+}
+
+// Handler serves the Result returned by result under /api/v1/result,
+// /api/v1/languages, /api/v1/languages/{lang}, and /api/v1/people. result
+// is called on every request, so a Result that's swapped out from under
+// Handler (e.g. by a background refresh) is picked up immediately.
+func Handler(result func() *stats.Result) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/api/v1/result", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, r, result())
+	})
+
+	mux.HandleFunc("/api/v1/languages", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, r, result().LanguageStats)
+	})
+
+	mux.HandleFunc("/api/v1/people", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, r, stats.People(result()))
+	})
+
+	mux.HandleFunc("/api/v1/languages/", func(w http.ResponseWriter, r *http.Request) {
+		language := strings.TrimPrefix(r.URL.Path, "/api/v1/languages/")
+		if language == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		languageStats := result().LanguageStats
+		for i := range languageStats {
+			if languageStats[i].Language == language {
+				writeJSON(w, r, languageStats[i])
+				return
+			}
+		}
+		http.NotFound(w, r)
+	})
+
+	return mux
+}
@@ -0,0 +1,284 @@
+// Package blame drives `git blame` over a set of files and aggregates the
+// results into per-author line counts, optionally consulting a sqlite cache
+// to skip files that haven't changed since they were last analysed.
+package blame
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akirak/contributors/pkg/identity"
+)
+
+// Contribution is one author's share of a file or language's blamed lines.
+// Email is the canonical identity contributions are aggregated under (a
+// canonical email address, or "bots"); RawEmails lists the distinct
+// author-mail addresses from `git blame` that were folded into it.
+type Contribution struct {
+	Email      string
+	Name       string
+	RawEmails  []string
+	Nlines     int
+	Percentage float64
+}
+
+// Contributions sorts by descending line count.
+type Contributions []Contribution
+
+func (a Contributions) Len() int           { return len(a) }
+func (a Contributions) Less(i, j int) bool { return a[i].Nlines > a[j].Nlines }
+func (a Contributions) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
+// Options configures Analyse.
+type Options struct {
+	// Root is the repository's working directory.
+	Root string
+	// Jobs is the number of parallel `git blame` workers. Values below 1
+	// default to runtime.NumCPU().
+	Jobs int
+	// Cache, when non-nil, is consulted before blaming a file and updated
+	// afterwards.
+	Cache *Cache
+	// Identity, when non-nil, canonicalises author-mail addresses via
+	// .mailmap and .contribauthors before they're aggregated.
+	Identity *identity.Resolver
+	// ExcludeBots drops the aggregated "bots" identity from the result.
+	ExcludeBots bool
+}
+
+// blameFile runs `git blame` on a single file and returns its per-author
+// line counts along with the file's total blamed line count.
+func blameFile(root, file string) (map[string]int, int, error) {
+	re, reErr := regexp.Compile(`^author-mail <(.+)>`)
+	if reErr != nil {
+		return nil, 0, fmt.Errorf("Regexp compile error: %v", reErr)
+	}
+
+	cmd := exec.Command("git", "blame", "--line-porcelain", "HEAD", "--", file)
+	cmd.Dir = root
+
+	stdout, pipeErr := cmd.StdoutPipe()
+	if pipeErr != nil {
+		return nil, 0, pipeErr
+	}
+
+	cmd.Start()
+
+	m := make(map[string]int)
+	var totalLines int
+
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		line := scanner.Text()
+		matched := re.MatchString(line)
+		if matched {
+			matches := re.FindStringSubmatch(line)
+			if len(matches) < 1 {
+				return nil, 0, fmt.Errorf("No submatch on %s", line)
+			}
+			author := matches[1]
+			n, ok := m[author]
+			if !ok {
+				m[author] = 1
+			} else {
+				m[author] = n + 1
+			}
+			totalLines++
+		}
+	}
+
+	scannerErr := scanner.Err()
+	if scannerErr != nil {
+		return nil, 0, fmt.Errorf("Error from scanner: %v", scannerErr)
+	}
+
+	return m, totalLines, nil
+}
+
+// lastModifyingCommit returns the SHA of the commit that last touched file,
+// which the blame cache uses to decide whether a re-blame is necessary.
+func lastModifyingCommit(root, file string) (string, error) {
+	cmd := exec.Command("git", "log", "-1", "--format=%H", "--", file)
+	cmd.Dir = root
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// HeadCommit returns the SHA of root's current HEAD commit, for callers
+// that want to detect when a repository has moved on since its last
+// analysis.
+func HeadCommit(root string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd.Dir = root
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// fileContributions blames a single file, consulting the cache first when
+// one is configured. It only shells out to `git blame` when the file's
+// last-modifying commit isn't already cached.
+func fileContributions(opts Options, language, file string) (map[string]int, int, error) {
+	if opts.Cache == nil {
+		return blameFile(opts.Root, file)
+	}
+
+	sha, shaErr := lastModifyingCommit(opts.Root, file)
+	if shaErr != nil {
+		return blameFile(opts.Root, file)
+	}
+
+	cached, lookupErr := opts.Cache.lookup(opts.Root, file)
+	if lookupErr != nil {
+		return nil, 0, lookupErr
+	}
+	if cached != nil && cached.CommitSHA == sha {
+		return cached.Contributions, cached.TotalLines, nil
+	}
+
+	contributions, totalLines, blameErr := blameFile(opts.Root, file)
+	if blameErr != nil {
+		return nil, 0, blameErr
+	}
+
+	// A failed cache write must not discard blame data we already have: the
+	// file just gets re-blamed next run instead of being dropped from this
+	// one's totals.
+	if storeErr := opts.Cache.store(opts.Root, file, cachedFileBlame{
+		CommitSHA:     sha,
+		Language:      language,
+		TotalLines:    totalLines,
+		Contributions: contributions,
+	}, time.Now()); storeErr != nil {
+		log.Printf("Error caching blame result for %s: %v", file, storeErr)
+	}
+
+	return contributions, totalLines, nil
+}
+
+// Analyse blames files through a pool of opts.Jobs worker goroutines,
+// aggregating their per-file results into a shared map guarded by a mutex.
+// A per-file blame error is logged and skipped rather than aborting the
+// whole language.
+func Analyse(opts Options, language string, files []string) ([]Contribution, int, error) {
+	jobs := opts.Jobs
+	if jobs < 1 {
+		jobs = runtime.NumCPU()
+	}
+	if jobs > len(files) {
+		jobs = len(files)
+	}
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	fileChan := make(chan string)
+	errChan := make(chan error, len(files))
+
+	type identityAggregate struct {
+		name      string
+		rawEmails map[string]bool
+		nlines    int
+	}
+
+	var mu sync.Mutex
+	aggregates := make(map[string]*identityAggregate)
+	var totalLines int
+
+	var wg sync.WaitGroup
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for file := range fileChan {
+				contributions, fileTotal, err := fileContributions(opts, language, file)
+				if err != nil {
+					errChan <- fmt.Errorf("Error while blaming %s: %v", file, err)
+					continue
+				}
+
+				// Resolve identities before taking the lock: checkMailmap
+				// shells out to `git check-mailmap` on a cache miss, and
+				// doing that while holding the one mutex every worker
+				// needs would serialize the whole pool.
+				resolved := make(map[string]identity.Identity, len(contributions))
+				for rawEmail := range contributions {
+					resolved[rawEmail] = identity.Resolve(opts.Identity, rawEmail)
+				}
+
+				mu.Lock()
+				for rawEmail, n := range contributions {
+					id := resolved[rawEmail]
+					agg, ok := aggregates[id.Canonical]
+					if !ok {
+						agg = &identityAggregate{name: id.Name, rawEmails: make(map[string]bool)}
+						aggregates[id.Canonical] = agg
+					}
+					agg.rawEmails[rawEmail] = true
+					agg.nlines += n
+				}
+				totalLines += fileTotal
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for i := range files {
+		fileChan <- files[i]
+	}
+	close(fileChan)
+
+	wg.Wait()
+	close(errChan)
+
+	for fileErr := range errChan {
+		log.Printf("%v", fileErr)
+	}
+
+	// Bot lines excluded from the output must also be excluded from the
+	// Percentage denominator, or the remaining contributors' percentages
+	// won't sum to 100%.
+	if opts.ExcludeBots {
+		if bots, ok := aggregates["bots"]; ok {
+			totalLines -= bots.nlines
+		}
+	}
+
+	var result []Contribution
+	for canonical, agg := range aggregates {
+		if opts.ExcludeBots && canonical == "bots" {
+			continue
+		}
+
+		var rawEmails []string
+		for rawEmail := range agg.rawEmails {
+			rawEmails = append(rawEmails, rawEmail)
+		}
+		sort.Strings(rawEmails)
+
+		result = append(result, Contribution{
+			Email:      canonical,
+			Name:       agg.name,
+			RawEmails:  rawEmails,
+			Nlines:     agg.nlines,
+			Percentage: float64(agg.nlines) / float64(totalLines) * 100,
+		})
+	}
+	sort.Sort(Contributions(result))
+	return result, totalLines, nil
+}
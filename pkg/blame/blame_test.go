@@ -0,0 +1,66 @@
+package blame
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// buildFixtureRepo creates a throwaway git repo under b.TempDir() with
+// numFiles Go files, each added in its own commit, for use as a blame
+// benchmark fixture.
+func buildFixtureRepo(b *testing.B, numFiles int) (string, []string) {
+	b.Helper()
+
+	dir := b.TempDir()
+
+	runGit := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Fixture", "GIT_AUTHOR_EMAIL=fixture@example.com",
+			"GIT_COMMITTER_NAME=Fixture", "GIT_COMMITTER_EMAIL=fixture@example.com")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			b.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	runGit("init")
+
+	var files []string
+	for i := 0; i < numFiles; i++ {
+		name := fmt.Sprintf("file%d.go", i)
+		content := fmt.Sprintf("package fixture\n\nvar N%d = %d\n", i, i)
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			b.Fatalf("WriteFile: %v", err)
+		}
+		files = append(files, name)
+		runGit("add", name)
+		runGit("commit", "-m", fmt.Sprintf("add %s", name))
+	}
+
+	return dir, files
+}
+
+func benchmarkAnalyse(b *testing.B, jobs int) {
+	dir, files := buildFixtureRepo(b, 50)
+	opts := Options{Root: dir, Jobs: jobs}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := Analyse(opts, "Go", files); err != nil {
+			b.Fatalf("Analyse: %v", err)
+		}
+	}
+}
+
+func BenchmarkAnalyseSerial(b *testing.B) {
+	benchmarkAnalyse(b, 1)
+}
+
+func BenchmarkAnalyseParallel(b *testing.B) {
+	benchmarkAnalyse(b, 8)
+}
@@ -0,0 +1,181 @@
+package blame
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+const createBlameCacheTableSQL = `
+CREATE TABLE IF NOT EXISTS blame_cache (
+	repo TEXT NOT NULL,
+	file_path TEXT NOT NULL,
+	commit_sha TEXT NOT NULL,
+	language TEXT NOT NULL,
+	total_lines INTEGER NOT NULL,
+	contributions TEXT NOT NULL,
+	analyzed_at INTEGER NOT NULL,
+	PRIMARY KEY (repo, file_path)
+)`
+
+type cachedFileBlame struct {
+	CommitSHA     string
+	Language      string
+	TotalLines    int
+	Contributions map[string]int
+}
+
+// Cache is a sqlite-backed cache of per-file blame results, keyed on repo
+// root + file path, so unchanged files don't need to be re-blamed.
+type Cache struct {
+	db *sql.DB
+}
+
+// OpenCache opens (creating if necessary) the sqlite cache at path.
+func OpenCache(path string) (*Cache, error) {
+	if mkdirErr := os.MkdirAll(filepath.Dir(path), 0o755); mkdirErr != nil {
+		return nil, mkdirErr
+	}
+
+	db, openErr := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if openErr != nil {
+		return nil, openErr
+	}
+
+	// The worker pool in Analyse calls store() from many goroutines at once;
+	// sqlite only allows one writer at a time, so route every query through
+	// a single connection rather than letting database/sql hand out several
+	// and fight over the database lock.
+	db.SetMaxOpenConns(1)
+
+	if _, execErr := db.Exec(createBlameCacheTableSQL); execErr != nil {
+		db.Close()
+		return nil, execErr
+	}
+
+	return &Cache{db: db}, nil
+}
+
+func (c *Cache) lookup(repo, file string) (*cachedFileBlame, error) {
+	row := c.db.QueryRow(
+		`SELECT commit_sha, language, total_lines, contributions FROM blame_cache WHERE repo = ? AND file_path = ?`,
+		repo, file)
+
+	var entry cachedFileBlame
+	var contributionsJSON string
+	scanErr := row.Scan(&entry.CommitSHA, &entry.Language, &entry.TotalLines, &contributionsJSON)
+	if scanErr != nil {
+		if errors.Is(scanErr, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, scanErr
+	}
+
+	if unmarshalErr := json.Unmarshal([]byte(contributionsJSON), &entry.Contributions); unmarshalErr != nil {
+		return nil, unmarshalErr
+	}
+
+	return &entry, nil
+}
+
+func (c *Cache) store(repo, file string, entry cachedFileBlame, analyzedAt time.Time) error {
+	contributionsJSON, marshalErr := json.Marshal(entry.Contributions)
+	if marshalErr != nil {
+		return marshalErr
+	}
+
+	_, execErr := c.db.Exec(`
+		INSERT INTO blame_cache (repo, file_path, commit_sha, language, total_lines, contributions, analyzed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(repo, file_path) DO UPDATE SET
+			commit_sha = excluded.commit_sha,
+			language = excluded.language,
+			total_lines = excluded.total_lines,
+			contributions = excluded.contributions,
+			analyzed_at = excluded.analyzed_at`,
+		repo, file, entry.CommitSHA, entry.Language, entry.TotalLines, string(contributionsJSON), analyzedAt.Unix())
+	return execErr
+}
+
+// Prune drops cache rows for repo whose file_path is not in present, and
+// returns the number of rows removed.
+func (c *Cache) Prune(repo string, present map[string]bool) (int64, error) {
+	rows, queryErr := c.db.Query(`SELECT file_path FROM blame_cache WHERE repo = ?`, repo)
+	if queryErr != nil {
+		return 0, queryErr
+	}
+
+	var stale []string
+	for rows.Next() {
+		var filePath string
+		if scanErr := rows.Scan(&filePath); scanErr != nil {
+			rows.Close()
+			return 0, scanErr
+		}
+		if !present[filePath] {
+			stale = append(stale, filePath)
+		}
+	}
+	if rowsErr := rows.Err(); rowsErr != nil {
+		return 0, rowsErr
+	}
+	rows.Close()
+
+	var pruned int64
+	for i := range stale {
+		execResult, execErr := c.db.Exec(`DELETE FROM blame_cache WHERE repo = ? AND file_path = ?`, repo, stale[i])
+		if execErr != nil {
+			return pruned, execErr
+		}
+		affected, _ := execResult.RowsAffected()
+		pruned += affected
+	}
+
+	return pruned, nil
+}
+
+// Close releases the underlying database handle.
+func (c *Cache) Close() error {
+	return c.db.Close()
+}
+
+// DefaultCachePath returns the default cache location for a repo named
+// repoName: ~/.cache/contributors/<repoName>.db.
+func DefaultCachePath(repoName string) (string, error) {
+	home, homeErr := os.UserHomeDir()
+	if homeErr != nil {
+		return "", homeErr
+	}
+	return filepath.Join(home, ".cache", "contributors", repoName+".db"), nil
+}
+
+// ListRepoFiles returns the set of paths currently tracked by git, relative
+// to root, for use by `contributors cache prune`.
+func ListRepoFiles(root string) (map[string]bool, error) {
+	cmd := exec.Command("git", "ls-files")
+	cmd.Dir = root
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	present := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		present[scanner.Text()] = true
+	}
+	if scannerErr := scanner.Err(); scannerErr != nil {
+		return nil, scannerErr
+	}
+
+	return present, nil
+}
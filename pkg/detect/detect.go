@@ -0,0 +1,117 @@
+// Package detect classifies the files in a repository by language.
+package detect
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/go-enry/go-enry/v2"
+)
+
+// RepoContents maps a language name to the list of files written in it,
+// relative to the repo root.
+type RepoContents map[string][]string
+
+// RunLinguist shells out to the external Ruby `linguist` binary. It is kept
+// as the --use-linguist fallback for anyone who wants the reference
+// classification.
+func RunLinguist(root string) (RepoContents, error) {
+	cmd := exec.Command("linguist", root, "--json")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+
+	if err != nil {
+		return nil, errors.New("Linguist failed")
+	}
+
+	var result RepoContents
+	json.Unmarshal(out, &result)
+	return result, err
+}
+
+// isVCSDir reports whether name is a version-control metadata directory
+// that should never be walked into or classified as source.
+func isVCSDir(name string) bool {
+	switch name {
+	case ".git", ".hg", ".svn", ".bzr":
+		return true
+	default:
+		return false
+	}
+}
+
+const sampleSize = 8 * 1024
+
+func readSample(p string, n int) ([]byte, error) {
+	file, openErr := os.Open(p)
+	if openErr != nil {
+		return nil, openErr
+	}
+	defer file.Close()
+
+	buf := make([]byte, n)
+	read, readErr := file.Read(buf)
+	if readErr != nil && readErr != io.EOF {
+		return nil, readErr
+	}
+	return buf[:read], nil
+}
+
+// RunEnry classifies the repo's files using the pure-Go go-enry library, so
+// users no longer need Ruby or github-linguist installed on PATH.
+func RunEnry(root string) (RepoContents, error) {
+	result := make(RepoContents)
+
+	walkErr := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			log.Printf("Error walking %s: %v", p, err)
+			if d != nil && d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if isVCSDir(d.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, relErr := filepath.Rel(root, p)
+		if relErr != nil {
+			log.Printf("Error resolving %s relative to %s: %v", p, root, relErr)
+			return nil
+		}
+
+		if enry.IsVendor(rel) || enry.IsDotFile(rel) || enry.IsDocumentation(rel) || enry.IsConfiguration(rel) {
+			return nil
+		}
+
+		content, readErr := readSample(p, sampleSize)
+		if readErr != nil {
+			log.Printf("Error reading %s, skipping: %v", rel, readErr)
+			return nil
+		}
+
+		language := enry.GetLanguage(rel, content)
+		if language == "" {
+			return nil
+		}
+
+		result[language] = append(result[language], rel)
+		return nil
+	})
+	if walkErr != nil {
+		return nil, walkErr
+	}
+
+	return result, nil
+}
@@ -0,0 +1,157 @@
+// Package identity canonicalises raw git author-mail addresses into stable
+// contributor identities, using the repo's .mailmap and an optional
+// .contribauthors configuration file.
+package identity
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Rule maps author-mail addresses matching Pattern (a regexp, which may
+// also just be a literal email address) to a canonical identity.
+type Rule struct {
+	Pattern   string `yaml:"pattern"`
+	Canonical string `yaml:"canonical"`
+	Name      string `yaml:"name"`
+}
+
+// Config is the contents of a repo's .contribauthors file.
+type Config struct {
+	Authors []Rule   `yaml:"authors"`
+	Bots    []string `yaml:"bots"`
+}
+
+// LoadConfig reads .contribauthors from root. A missing file yields a zero
+// Config rather than an error.
+func LoadConfig(root string) (*Config, error) {
+	configFile := filepath.Join(root, ".contribauthors")
+	contents, readErr := os.ReadFile(configFile)
+	if readErr != nil {
+		if errors.Is(readErr, os.ErrNotExist) {
+			return &Config{}, nil
+		}
+		return nil, readErr
+	}
+
+	var config Config
+	if err := yaml.Unmarshal(contents, &config); err != nil {
+		return nil, fmt.Errorf("Error parsing .contribauthors: %v", err)
+	}
+	return &config, nil
+}
+
+// Identity is the canonical identity a raw author-mail address resolves to.
+type Identity struct {
+	// Canonical is the stable key contributions are aggregated under: a
+	// canonical email address, or "bots" for a recognised bot account.
+	Canonical string
+	// Name is the display name shown to users.
+	Name string
+}
+
+type compiledRule struct {
+	re        *regexp.Regexp
+	canonical string
+	name      string
+}
+
+// Resolver canonicalises raw author-mail addresses via the repo's .mailmap,
+// followed by .contribauthors author rules and bot patterns.
+type Resolver struct {
+	root  string
+	rules []compiledRule
+	bots  []*regexp.Regexp
+
+	mu      sync.Mutex
+	mailmap map[string]string
+}
+
+// NewResolver compiles config's rules for lookups against repo root.
+func NewResolver(root string, config *Config) (*Resolver, error) {
+	r := &Resolver{root: root, mailmap: make(map[string]string)}
+
+	for _, rule := range config.Authors {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("Error compiling author pattern %q: %v", rule.Pattern, err)
+		}
+		r.rules = append(r.rules, compiledRule{re: re, canonical: rule.Canonical, name: rule.Name})
+	}
+
+	for _, pattern := range config.Bots {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("Error compiling bot pattern %q: %v", pattern, err)
+		}
+		r.bots = append(r.bots, re)
+	}
+
+	return r, nil
+}
+
+// checkMailmap canonicalises email via `git check-mailmap`, caching results
+// since the same address is looked up again for every file it appears in.
+func (r *Resolver) checkMailmap(email string) string {
+	r.mu.Lock()
+	if canonical, ok := r.mailmap[email]; ok {
+		r.mu.Unlock()
+		return canonical
+	}
+	r.mu.Unlock()
+
+	canonical := email
+	cmd := exec.Command("git", "check-mailmap", "<"+email+">")
+	cmd.Dir = r.root
+	if out, err := cmd.Output(); err == nil {
+		// The output is "<mail>" or "Name <mail>"; keep only the address.
+		trimmed := strings.TrimSpace(string(out))
+		start, end := strings.LastIndex(trimmed, "<"), strings.LastIndex(trimmed, ">")
+		if start >= 0 && end > start+1 {
+			canonical = trimmed[start+1 : end]
+		}
+	}
+
+	r.mu.Lock()
+	r.mailmap[email] = canonical
+	r.mu.Unlock()
+
+	return canonical
+}
+
+// Resolve canonicalises a raw author-mail address from `git blame`.
+func (r *Resolver) Resolve(email string) Identity {
+	canonical := r.checkMailmap(email)
+
+	for _, rule := range r.rules {
+		if rule.re.MatchString(canonical) {
+			return Identity{Canonical: rule.canonical, Name: rule.name}
+		}
+	}
+
+	for _, bot := range r.bots {
+		if bot.MatchString(canonical) {
+			return Identity{Canonical: "bots", Name: "Bots"}
+		}
+	}
+
+	return Identity{Canonical: canonical, Name: canonical}
+}
+
+// Resolve canonicalises email using r, tolerating a nil Resolver (returning
+// the address unchanged) so callers don't need to special-case "no
+// .contribauthors file".
+func Resolve(r *Resolver, email string) Identity {
+	if r == nil {
+		return Identity{Canonical: email, Name: email}
+	}
+	return r.Resolve(email)
+}
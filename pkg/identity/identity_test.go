@@ -0,0 +1,66 @@
+package identity
+
+import "testing"
+
+func TestResolverResolve(t *testing.T) {
+	config := &Config{
+		Authors: []Rule{
+			{Pattern: `^alice@(personal|work)\.example$`, Canonical: "alice@work.example", Name: "Alice"},
+		},
+		Bots: []string{`\[bot\]@users\.noreply\.github\.com$`},
+	}
+
+	resolver, err := NewResolver(t.TempDir(), config)
+	if err != nil {
+		t.Fatalf("NewResolver: %v", err)
+	}
+
+	cases := []struct {
+		name          string
+		email         string
+		wantCanonical string
+		wantName      string
+	}{
+		{
+			name:          "matches author rule from personal address",
+			email:         "alice@personal.example",
+			wantCanonical: "alice@work.example",
+			wantName:      "Alice",
+		},
+		{
+			name:          "matches author rule from work address",
+			email:         "alice@work.example",
+			wantCanonical: "alice@work.example",
+			wantName:      "Alice",
+		},
+		{
+			name:          "matches bot pattern",
+			email:         "49699333+dependabot[bot]@users.noreply.github.com",
+			wantCanonical: "bots",
+			wantName:      "Bots",
+		},
+		{
+			name:          "falls back to the address itself",
+			email:         "bob@example.com",
+			wantCanonical: "bob@example.com",
+			wantName:      "bob@example.com",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := resolver.Resolve(c.email)
+			if got.Canonical != c.wantCanonical || got.Name != c.wantName {
+				t.Errorf("Resolve(%q) = %+v, want {%q %q}", c.email, got, c.wantCanonical, c.wantName)
+			}
+		})
+	}
+}
+
+func TestResolveNilResolver(t *testing.T) {
+	got := Resolve(nil, "bob@example.com")
+	want := Identity{Canonical: "bob@example.com", Name: "bob@example.com"}
+	if got != want {
+		t.Errorf("Resolve(nil, ...) = %+v, want %+v", got, want)
+	}
+}
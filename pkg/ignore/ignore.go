@@ -0,0 +1,82 @@
+// Package ignore parses a repo's .contribignore file and applies its glob
+// patterns to exclude files from analysis.
+package ignore
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+
+	"github.com/bmatcuk/doublestar"
+)
+
+// GlobPattern is a single line of a .contribignore file.
+type GlobPattern = string
+
+// LoadPatterns reads the .contribignore file in root, skipping blank and
+// comment lines. A missing file is not an error; it yields no patterns.
+func LoadPatterns(root string) ([]GlobPattern, error) {
+	ignoreFile := path.Join(root, ".contribignore")
+	_, statErr := os.Stat(ignoreFile)
+	if statErr != nil {
+		if errors.Is(statErr, os.ErrNotExist) {
+			var result []string
+			return result, nil
+		}
+		return nil, statErr
+	}
+	file, fileErr := os.Open(ignoreFile)
+	if fileErr != nil {
+		return nil, fileErr
+	}
+
+	re, reErr := regexp.Compile(`^(\s*#|\s*$)`)
+	if reErr != nil {
+		return nil, reErr
+	}
+
+	scanner := bufio.NewScanner(file)
+	var result []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !re.MatchString(line) {
+			result = append(result, line)
+		}
+	}
+
+	scannerErr := scanner.Err()
+	if scannerErr != nil {
+		return nil, fmt.Errorf("Error from scanner: %v", scannerErr)
+	}
+
+	return result, nil
+}
+
+// Exclude returns the subset of files that match none of ignorePatterns.
+func Exclude(ignorePatterns []GlobPattern, files []string) ([]string, error) {
+	var result []string
+
+	for i := range files {
+		file := files[i]
+		var ignored bool = false
+		for j := range ignorePatterns {
+			x, matchErr := doublestar.Match(ignorePatterns[j], file)
+			if matchErr != nil {
+				return nil, fmt.Errorf("Error while matching %s on %s: %v",
+					ignorePatterns[j], file,
+					matchErr)
+			}
+			if x {
+				ignored = true
+				break
+			}
+		}
+		if !ignored {
+			result = append(result, file)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,89 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadPatterns(t *testing.T) {
+	cases := []struct {
+		name     string
+		contents string
+		haveFile bool
+		want     []GlobPattern
+	}{
+		{
+			name:     "missing file",
+			haveFile: false,
+			want:     nil,
+		},
+		{
+			name:     "skips comments and blank lines",
+			haveFile: true,
+			contents: "# a comment\nvendor/**\n\n*.min.js\n",
+			want:     []GlobPattern{"vendor/**", "*.min.js"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			root := t.TempDir()
+			if c.haveFile {
+				path := filepath.Join(root, ".contribignore")
+				if err := os.WriteFile(path, []byte(c.contents), 0o644); err != nil {
+					t.Fatalf("WriteFile: %v", err)
+				}
+			}
+
+			got, err := LoadPatterns(root)
+			if err != nil {
+				t.Fatalf("LoadPatterns: %v", err)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("LoadPatterns() = %#v, want %#v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestExclude(t *testing.T) {
+	cases := []struct {
+		name     string
+		patterns []GlobPattern
+		files    []string
+		want     []string
+	}{
+		{
+			name:     "no patterns",
+			patterns: nil,
+			files:    []string{"main.go", "vendor/lib.go"},
+			want:     []string{"main.go", "vendor/lib.go"},
+		},
+		{
+			name:     "excludes matching glob",
+			patterns: []GlobPattern{"vendor/**"},
+			files:    []string{"main.go", "vendor/lib.go"},
+			want:     []string{"main.go"},
+		},
+		{
+			name:     "excludes multiple patterns",
+			patterns: []GlobPattern{"*.min.js", "vendor/**"},
+			files:    []string{"app.js", "app.min.js", "vendor/lib.go"},
+			want:     []string{"app.js"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := Exclude(c.patterns, c.files)
+			if err != nil {
+				t.Fatalf("Exclude: %v", err)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("Exclude() = %#v, want %#v", got, c.want)
+			}
+		})
+	}
+}
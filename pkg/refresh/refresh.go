@@ -0,0 +1,137 @@
+// Package refresh keeps a served stats.Result up to date in the
+// background, re-running an analysis when the repository's HEAD commit
+// changes or when a configured interval elapses, without blocking readers.
+package refresh
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/akirak/contributors/pkg/blame"
+	"github.com/akirak/contributors/pkg/stats"
+)
+
+// Store holds the most recently analysed Result. Load is safe to call
+// concurrently with Store, including while a background refresh is
+// swapping in a new Result.
+type Store struct {
+	result atomic.Pointer[stats.Result]
+}
+
+// Load returns the current Result.
+func (s *Store) Load() *stats.Result {
+	return s.result.Load()
+}
+
+// Store replaces the current Result.
+func (s *Store) Store(result *stats.Result) {
+	s.result.Store(result)
+}
+
+// AnalyseFunc runs a full repository analysis and returns the resulting
+// Result.
+type AnalyseFunc func() (*stats.Result, error)
+
+// defaultPollInterval is how often Run checks whether HEAD has moved,
+// independent of RefreshInterval.
+const defaultPollInterval = 30 * time.Second
+
+// Scheduler periodically re-runs Analyse and swaps the refreshed Result
+// into Store, either because the repository's HEAD commit changed or
+// because RefreshInterval has elapsed since the last analysis.
+type Scheduler struct {
+	root            string
+	refreshInterval time.Duration
+	analyse         AnalyseFunc
+	store           *Store
+
+	mu          sync.Mutex
+	lastSHA     string
+	lastRefresh time.Time
+}
+
+// NewScheduler constructs a Scheduler for root, seeded with the commit SHA
+// and timestamp of the Result store already holds (the one analyse() was
+// called to produce before Run starts), so the first poll tick doesn't
+// mistake that initial analysis for a HEAD change and re-run it for
+// nothing. refreshInterval of zero disables the unconditional timer-based
+// refresh; HEAD-change detection still applies.
+func NewScheduler(root string, refreshInterval time.Duration, analyse AnalyseFunc, store *Store) *Scheduler {
+	s := &Scheduler{
+		root:            root,
+		refreshInterval: refreshInterval,
+		analyse:         analyse,
+		store:           store,
+	}
+	if initial := store.Load(); initial != nil {
+		s.lastSHA = initial.CommitSHA
+		s.lastRefresh = initial.AnalysedAt
+	}
+	return s
+}
+
+// dueForRefresh reports whether a refresh should run because sha differs
+// from the last analysed commit, or because refreshInterval (when
+// positive) has elapsed since lastRefresh.
+func dueForRefresh(sha, lastSHA string, lastRefresh time.Time, refreshInterval time.Duration, now time.Time) bool {
+	if sha != lastSHA {
+		return true
+	}
+	return refreshInterval > 0 && now.Sub(lastRefresh) >= refreshInterval
+}
+
+// Run polls for a changed HEAD commit or an elapsed RefreshInterval every
+// defaultPollInterval, refreshing Store when either fires. It blocks until
+// stop is closed.
+func (s *Scheduler) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(defaultPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			sha, err := blame.HeadCommit(s.root)
+			if err != nil {
+				log.Printf("Error checking HEAD: %v", err)
+				continue
+			}
+
+			s.mu.Lock()
+			due := dueForRefresh(sha, s.lastSHA, s.lastRefresh, s.refreshInterval, time.Now())
+			s.mu.Unlock()
+			if !due {
+				continue
+			}
+
+			if err := s.Refresh(); err != nil {
+				log.Printf("Error refreshing: %v", err)
+			}
+		}
+	}
+}
+
+// Refresh runs Analyse immediately and swaps the result into Store. It's
+// exported so an HTTP handler (e.g. POST /refresh) can trigger a rebuild
+// on demand, concurrently with Run's own polling.
+func (s *Scheduler) Refresh() error {
+	result, err := s.analyse()
+	if err != nil {
+		return err
+	}
+	s.store.Store(result)
+
+	sha, shaErr := blame.HeadCommit(s.root)
+
+	s.mu.Lock()
+	if shaErr == nil {
+		s.lastSHA = sha
+	}
+	s.lastRefresh = time.Now()
+	s.mu.Unlock()
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package refresh
+
+import (
+	"testing"
+	"time"
+
+	"github.com/akirak/contributors/pkg/stats"
+)
+
+func TestStoreLoadStore(t *testing.T) {
+	var store Store
+
+	if got := store.Load(); got != nil {
+		t.Fatalf("Load() on a zero Store = %v, want nil", got)
+	}
+
+	result := &stats.Result{CommitSHA: "abc123"}
+	store.Store(result)
+
+	if got := store.Load(); got != result {
+		t.Fatalf("Load() = %v, want %v", got, result)
+	}
+}
+
+func TestNewSchedulerSeedsFromStore(t *testing.T) {
+	analysedAt := time.Unix(1700000000, 0)
+
+	var store Store
+	store.Store(&stats.Result{CommitSHA: "initial-sha", AnalysedAt: analysedAt})
+
+	scheduler := NewScheduler("/repo", 0, nil, &store)
+
+	if scheduler.lastSHA != "initial-sha" {
+		t.Errorf("lastSHA = %q, want %q", scheduler.lastSHA, "initial-sha")
+	}
+	if !scheduler.lastRefresh.Equal(analysedAt) {
+		t.Errorf("lastRefresh = %v, want %v", scheduler.lastRefresh, analysedAt)
+	}
+
+	// Without the seed, the very next poll would see sha != "" and trigger
+	// a wasted re-analysis even though HEAD hasn't moved.
+	if dueForRefresh("initial-sha", scheduler.lastSHA, scheduler.lastRefresh, 0, analysedAt.Add(time.Second)) {
+		t.Errorf("dueForRefresh() = true right after seeding, want false")
+	}
+}
+
+func TestNewSchedulerWithEmptyStore(t *testing.T) {
+	var store Store
+	scheduler := NewScheduler("/repo", 0, nil, &store)
+
+	if scheduler.lastSHA != "" {
+		t.Errorf("lastSHA = %q, want empty", scheduler.lastSHA)
+	}
+}
+
+func TestDueForRefresh(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	cases := []struct {
+		name            string
+		sha             string
+		lastSHA         string
+		lastRefresh     time.Time
+		refreshInterval time.Duration
+		want            bool
+	}{
+		{
+			name:    "HEAD changed",
+			sha:     "new-sha",
+			lastSHA: "old-sha",
+			want:    true,
+		},
+		{
+			name:        "HEAD unchanged, no interval configured",
+			sha:         "same-sha",
+			lastSHA:     "same-sha",
+			lastRefresh: now.Add(-time.Hour),
+			want:        false,
+		},
+		{
+			name:            "HEAD unchanged, interval not yet elapsed",
+			sha:             "same-sha",
+			lastSHA:         "same-sha",
+			lastRefresh:     now.Add(-time.Minute),
+			refreshInterval: time.Hour,
+			want:            false,
+		},
+		{
+			name:            "HEAD unchanged, interval elapsed",
+			sha:             "same-sha",
+			lastSHA:         "same-sha",
+			lastRefresh:     now.Add(-2 * time.Hour),
+			refreshInterval: time.Hour,
+			want:            true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := dueForRefresh(c.sha, c.lastSHA, c.lastRefresh, c.refreshInterval, now)
+			if got != c.want {
+				t.Errorf("dueForRefresh(...) = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
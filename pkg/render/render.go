@@ -0,0 +1,200 @@
+// Package render writes the HTML report for a stats.Result.
+package render
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/akirak/contributors/pkg/blame"
+	"github.com/akirak/contributors/pkg/stats"
+)
+
+// Options configures the rendered report.
+type Options struct {
+	// Name is the repo's display name, used in the page title.
+	Name string
+	// Threshold folds per-language contributors below this many lines into
+	// an "N others" row; the last contributor in the list is always shown.
+	Threshold int
+}
+
+func stripDomain(email string) (string, error) {
+	re, reError := regexp.Compile("^.+@")
+	if reError != nil {
+		return email, reError
+	}
+	return re.FindString(email), nil
+}
+
+// displayIdentity returns the name shown for a contributor: the canonical
+// display name from a matching .contribauthors rule (or "Bots") when one was
+// resolved, otherwise the contributor's email with its domain stripped.
+func displayIdentity(c blame.Contribution) string {
+	if c.Name != "" && c.Name != c.Email {
+		return c.Name
+	}
+	stripped, _ := stripDomain(c.Email)
+	return stripped
+}
+
+// hoverEmails returns the raw author-mail addresses folded into c, for use
+// as a hover title, falling back to c.Email when none were recorded.
+func hoverEmails(c blame.Contribution) string {
+	if len(c.RawEmails) > 0 {
+		return strings.Join(c.RawEmails, ", ")
+	}
+	return c.Email
+}
+
+func FormatPercent(percent float64) string {
+	if percent < 10 {
+		return fmt.Sprintf("%.2f", percent)
+	} else {
+		return fmt.Sprintf("%.1f", percent)
+	}
+}
+
+func LanguageProfile(result *stats.Result, w io.Writer) {
+	languageStats := result.LanguageStats
+	fmt.Fprintln(w, "<table>")
+	fmt.Fprintln(w, "<thead><tr>")
+	fmt.Fprint(w, "<th>Language</th>")
+	fmt.Fprint(w, "<th>Files</th>")
+	fmt.Fprint(w, "<th>Lines</th>")
+	fmt.Fprint(w, "<th>Percentage</th>")
+	fmt.Fprint(w, "</tr>")
+	fmt.Fprintln(w, "<tbody>")
+	var totalLines int = 0
+	for i := range languageStats {
+		totalLines += languageStats[i].TotalLines
+	}
+	for i := range languageStats {
+		stat := languageStats[i]
+		fmt.Fprintf(w, "<tr>")
+		fmt.Fprintf(w, "<td>%s</td>", stat.Language)
+		fmt.Fprintf(w, "<td>%d</td>", len(stat.Files))
+		fmt.Fprintf(w, "<td>%d</td>", stat.TotalLines)
+		percentage := float64(stat.TotalLines) / float64(totalLines) * 100
+		fmt.Fprintf(w, "<td>%s%%</td>", FormatPercent(percentage))
+		fmt.Fprintln(w, "</tr>")
+	}
+	fmt.Fprintln(w, "</tbody>")
+	fmt.Fprintln(w, "</table>")
+}
+
+func PeopleProfile(result *stats.Result, w io.Writer) {
+	contributions := stats.People(result)
+
+	fmt.Fprintln(w, "<table>")
+	fmt.Fprintln(w, "<thead><tr>")
+	fmt.Fprint(w, "<th>Person</th>")
+	fmt.Fprint(w, "<th># lines</th>")
+	fmt.Fprint(w, "<th>%</th>")
+	fmt.Fprint(w, "</tr>")
+	fmt.Fprintln(w, "<tbody>")
+	var remainingPercentage float64 = 100
+	var remainingContributors int = 0
+	numContributors := len(contributions)
+	for i := range contributions {
+		c := contributions[i]
+		percentage := c.Percentage
+		nlines := c.Nlines
+		// TODO: Make this threshold customizable
+		if nlines < 50 && percentage < 10 && i < numContributors-1 {
+			remainingContributors = numContributors - i
+			break
+		}
+		remainingPercentage -= percentage
+		fmt.Fprint(w, "<tr>")
+		fmt.Fprintf(w, "<td><span title=\"%s\">%s</span></td>", hoverEmails(c), displayIdentity(c))
+		fmt.Fprintf(w, "<td>%d</td>", nlines)
+		fmt.Fprintf(w, "<td>%s%%</td>", FormatPercent(percentage))
+		fmt.Fprintln(w, "</tr>")
+	}
+	if remainingContributors > 0 {
+		fmt.Fprint(w, "<tr>")
+		fmt.Fprintf(w, "<td>%d others</td>", remainingContributors)
+		fmt.Fprint(w, "<td>-</td>")
+		fmt.Fprintf(w, "<td>%s%%</td>", FormatPercent(remainingPercentage))
+		fmt.Fprintln(w, "</tr>")
+	}
+	fmt.Fprintln(w, "</tbody>")
+	fmt.Fprintln(w, "</table>")
+}
+
+func LanguageStat(opts Options, stat *stats.LanguageStat, w io.Writer) {
+	fmt.Fprintf(w, "<h3>%s</h3>\n", stat.Language)
+
+	fmt.Fprintln(w, "<details>")
+	fmt.Fprintln(w, "<summary>Files</summary>")
+	fmt.Fprintln(w, "<ul>")
+	for j := range stat.Files {
+		fmt.Fprintf(w, "<li>%s</li>", stat.Files[j])
+	}
+	fmt.Fprintln(w, "</ul>")
+	fmt.Fprintln(w, "</details>")
+
+	fmt.Fprintln(w, "<table>")
+	fmt.Fprintln(w, "<caption>Contributors</caption>")
+	fmt.Fprintln(w, "<thead>")
+	fmt.Fprintln(w, "<tr>")
+	fmt.Fprintln(w, "<th>E-mail</th>")
+	fmt.Fprintln(w, "<th>Lines</th>")
+	fmt.Fprintln(w, "<th>Percent</th>")
+	fmt.Fprintln(w, "</tr>")
+	fmt.Fprintln(w, "</thead>")
+	fmt.Fprintln(w, "<tbody>")
+	var remainingPercentage float64 = 100
+	var remainingContributors int = 0
+	numContributors := len(stat.Contributions)
+	for j := range stat.Contributions {
+		c := stat.Contributions[j]
+		percentage := c.Percentage
+		nlines := c.Nlines
+		if nlines < opts.Threshold && j < numContributors-1 {
+			remainingContributors = numContributors - j
+			break
+		}
+		remainingPercentage -= percentage
+		fmt.Fprint(w, "<tr>")
+		fmt.Fprintf(w, "<td><span title=\"%s\">%s</span></td>", hoverEmails(c), displayIdentity(c))
+		fmt.Fprintf(w, "<td>%d</td>", nlines)
+		fmt.Fprintf(w, "<td>%s%%</td>", FormatPercent(percentage))
+		fmt.Fprintln(w, "</tr>")
+	}
+	if remainingContributors > 0 {
+		fmt.Fprint(w, "<tr>")
+		fmt.Fprintf(w, "<td>%d others</td>", remainingContributors)
+		fmt.Fprint(w, "<td>-</td>")
+		fmt.Fprintf(w, "<td>%s%%</td>", FormatPercent(remainingPercentage))
+		fmt.Fprintln(w, "</tr>")
+	}
+	fmt.Fprintln(w, "</tbody>")
+	fmt.Fprintln(w, "</table>")
+
+}
+
+func HandleHome(opts Options, result *stats.Result, w io.Writer) {
+	languageStats := result.LanguageStats
+	title := fmt.Sprintf("Contributions to %s", opts.Name)
+	fmt.Fprintf(w, "<title>%s</title>\n", title)
+	fmt.Fprintf(w, "<h1>%s</h1>\n", title)
+
+	if result.CommitSHA != "" {
+		fmt.Fprintf(w, "<p>Analysed commit <code>%s</code> at %s</p>\n",
+			result.CommitSHA, result.AnalysedAt.Format(time.RFC3339))
+	}
+
+	fmt.Fprintln(w, "<h2>Languages</h2>")
+	LanguageProfile(result, w)
+	fmt.Fprintln(w, "<h2>People</h2>")
+	PeopleProfile(result, w)
+
+	fmt.Fprintln(w, "<h2>Contributions by language</h2>")
+	for i := range languageStats {
+		LanguageStat(opts, &languageStats[i], w)
+	}
+}
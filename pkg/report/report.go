@@ -0,0 +1,81 @@
+// Package report writes a stats.Result to a machine-readable format for
+// one-shot, non-served runs (--no-serve).
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/akirak/contributors/pkg/stats"
+)
+
+// Format is a one-shot report's output format.
+type Format string
+
+const (
+	JSON   Format = "json"
+	CSV    Format = "csv"
+	NDJSON Format = "ndjson"
+)
+
+// Write renders result to w in the given format.
+func Write(format Format, result *stats.Result, w io.Writer) error {
+	switch format {
+	case JSON:
+		return writeJSON(result, w)
+	case NDJSON:
+		return writeNDJSON(result, w)
+	case CSV:
+		return writeCSV(result, w)
+	default:
+		return fmt.Errorf("Unsupported output format: %s", format)
+	}
+}
+
+func writeJSON(result *stats.Result, w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
+
+// writeNDJSON emits one LanguageStat per line, so it can be streamed and
+// processed line by line.
+func writeNDJSON(result *stats.Result, w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	for i := range result.LanguageStats {
+		if err := encoder.Encode(result.LanguageStats[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeCSV(result *stats.Result, w io.Writer) error {
+	writer := csv.NewWriter(w)
+	defer writer.Flush()
+
+	if err := writer.Write([]string{"language", "email", "name", "nlines", "percentage"}); err != nil {
+		return err
+	}
+
+	for i := range result.LanguageStats {
+		stat := result.LanguageStats[i]
+		for j := range stat.Contributions {
+			c := stat.Contributions[j]
+			row := []string{
+				stat.Language,
+				c.Email,
+				c.Name,
+				strconv.Itoa(c.Nlines),
+				strconv.FormatFloat(c.Percentage, 'f', 2, 64),
+			}
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+// Package stats aggregates per-language blame results into the Result
+// that's served to clients.
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/akirak/contributors/pkg/blame"
+	"github.com/akirak/contributors/pkg/detect"
+	"github.com/akirak/contributors/pkg/identity"
+	"github.com/akirak/contributors/pkg/ignore"
+)
+
+// LanguageStat is one language's files and contributor breakdown.
+type LanguageStat struct {
+	Language      string
+	Files         []string
+	TotalLines    int
+	Contributions []blame.Contribution
+}
+
+// LanguageStats sorts by descending total line count.
+type LanguageStats []LanguageStat
+
+func (a LanguageStats) Len() int           { return len(a) }
+func (a LanguageStats) Less(i, j int) bool { return a[i].TotalLines > a[j].TotalLines }
+func (a LanguageStats) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
+// Result is the full analysis of a repository.
+type Result struct {
+	Contents      detect.RepoContents
+	LanguageStats []LanguageStat
+	// CommitSHA is the repository's HEAD commit at the time of analysis,
+	// so long-running servers can report how fresh a Result is.
+	CommitSHA string
+	// AnalysedAt is when this Result was computed.
+	AnalysedAt time.Time
+}
+
+// Options configures Compute.
+type Options struct {
+	// Root is the repository's working directory.
+	Root string
+	// Jobs is forwarded to blame.Analyse.
+	Jobs int
+	// Cache, when non-nil, is forwarded to blame.Analyse.
+	Cache *blame.Cache
+	// Identity, when non-nil, is forwarded to blame.Analyse.
+	Identity *identity.Resolver
+	// ExcludeBots is forwarded to blame.Analyse.
+	ExcludeBots bool
+}
+
+// People aggregates every language's contributions into one per-author
+// total across the whole repository.
+func People(result *Result) []blame.Contribution {
+	type aggregate struct {
+		name      string
+		rawEmails map[string]bool
+		nlines    int
+	}
+
+	aggregates := make(map[string]*aggregate)
+	var totalLines int
+	for i := range result.LanguageStats {
+		totalLines += result.LanguageStats[i].TotalLines
+		for _, c := range result.LanguageStats[i].Contributions {
+			agg, ok := aggregates[c.Email]
+			if !ok {
+				agg = &aggregate{name: c.Name, rawEmails: make(map[string]bool)}
+				aggregates[c.Email] = agg
+			}
+			for _, rawEmail := range c.RawEmails {
+				agg.rawEmails[rawEmail] = true
+			}
+			agg.nlines += c.Nlines
+		}
+	}
+
+	var contributions []blame.Contribution
+	for email, agg := range aggregates {
+		var rawEmails []string
+		for rawEmail := range agg.rawEmails {
+			rawEmails = append(rawEmails, rawEmail)
+		}
+		sort.Strings(rawEmails)
+
+		contributions = append(contributions, blame.Contribution{
+			Email:      email,
+			Name:       agg.name,
+			RawEmails:  rawEmails,
+			Nlines:     agg.nlines,
+			Percentage: float64(agg.nlines) / float64(totalLines) * 100,
+		})
+	}
+	sort.Sort(blame.Contributions(contributions))
+	return contributions
+}
+
+// Compute filters contents through the repo's .contribignore and blames the
+// remaining files language by language.
+func Compute(opts Options, contents detect.RepoContents) ([]LanguageStat, error) {
+	var result []LanguageStat
+
+	ignorePatterns, ignoreFileErr := ignore.LoadPatterns(opts.Root)
+	if ignoreFileErr != nil {
+		return nil, fmt.Errorf("Error from the ignore file: %v", ignoreFileErr)
+	}
+
+	for language, unfilteredFiles := range contents {
+		files, filterErr := ignore.Exclude(ignorePatterns, unfilteredFiles)
+		if filterErr != nil {
+			return nil, filterErr
+		}
+		contributions, totalLines, err := blame.Analyse(blame.Options{
+			Root:        opts.Root,
+			Jobs:        opts.Jobs,
+			Cache:       opts.Cache,
+			Identity:    opts.Identity,
+			ExcludeBots: opts.ExcludeBots,
+		}, language, files)
+		if err != nil {
+			return nil, fmt.Errorf("Error while analysing %s: %v", language, err)
+		}
+		result = append(result, LanguageStat{
+			Language:      language,
+			Files:         files,
+			TotalLines:    totalLines,
+			Contributions: contributions,
+		})
+	}
+	sort.Sort(LanguageStats(result))
+	return result, nil
+}
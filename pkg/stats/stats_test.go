@@ -0,0 +1,133 @@
+package stats
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/akirak/contributors/pkg/blame"
+)
+
+func TestLanguageStatsSort(t *testing.T) {
+	cases := []struct {
+		name  string
+		stats LanguageStats
+		want  []string
+	}{
+		{
+			name: "already descending",
+			stats: LanguageStats{
+				{Language: "Go", TotalLines: 100},
+				{Language: "HTML", TotalLines: 10},
+			},
+			want: []string{"Go", "HTML"},
+		},
+		{
+			name: "needs reordering",
+			stats: LanguageStats{
+				{Language: "HTML", TotalLines: 10},
+				{Language: "Go", TotalLines: 100},
+				{Language: "YAML", TotalLines: 50},
+			},
+			want: []string{"Go", "YAML", "HTML"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sort.Sort(c.stats)
+
+			var got []string
+			for _, stat := range c.stats {
+				got = append(got, stat.Language)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("sort order = %#v, want %#v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestPeople(t *testing.T) {
+	cases := []struct {
+		name   string
+		result *Result
+		want   []blame.Contribution
+	}{
+		{
+			name: "merges one author's contributions across languages",
+			result: &Result{
+				LanguageStats: []LanguageStat{
+					{
+						TotalLines: 80,
+						Contributions: []blame.Contribution{
+							{Email: "alice@example.com", Name: "Alice", RawEmails: []string{"alice@work.com"}, Nlines: 60},
+							{Email: "bob@example.com", Name: "Bob", RawEmails: []string{"bob@work.com"}, Nlines: 20},
+						},
+					},
+					{
+						TotalLines: 20,
+						Contributions: []blame.Contribution{
+							{Email: "alice@example.com", Name: "Alice", RawEmails: []string{"alice@personal.com"}, Nlines: 20},
+						},
+					},
+				},
+			},
+			want: []blame.Contribution{
+				{
+					Email:      "alice@example.com",
+					Name:       "Alice",
+					RawEmails:  []string{"alice@personal.com", "alice@work.com"},
+					Nlines:     80,
+					Percentage: 80,
+				},
+				{
+					Email:      "bob@example.com",
+					Name:       "Bob",
+					RawEmails:  []string{"bob@work.com"},
+					Nlines:     20,
+					Percentage: 20,
+				},
+			},
+		},
+		{
+			name:   "no languages analysed",
+			result: &Result{},
+			want:   nil,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := People(c.result)
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("People(...) = %#v, want %#v", got, c.want)
+			}
+		})
+	}
+}
+
+// A repository whose languages all blame to zero lines (e.g. everything is
+// excluded) leaves totalLines at zero; Percentage's division then produces
+// NaN rather than panicking or erroring.
+func TestPeopleTotalLinesZero(t *testing.T) {
+	result := &Result{
+		LanguageStats: []LanguageStat{
+			{
+				TotalLines: 0,
+				Contributions: []blame.Contribution{
+					{Email: "alice@example.com", Name: "Alice", RawEmails: []string{"alice@work.com"}, Nlines: 0},
+				},
+			},
+		},
+	}
+
+	got := People(result)
+	if len(got) != 1 {
+		t.Fatalf("People(...) = %#v, want 1 contribution", got)
+	}
+	if !math.IsNaN(got[0].Percentage) {
+		t.Errorf("Percentage = %v, want NaN", got[0].Percentage)
+	}
+}